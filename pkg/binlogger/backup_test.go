@@ -0,0 +1,187 @@
+package gobinlogger_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	gobinlogger "github.com/harikube/go-binlogger/pkg/binlogger"
+)
+
+func TestBinLoggerBackupRestoreRoundTrip(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry"), []byte("third entry"), []byte("fourth entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	var buf bytes.Buffer
+	finalIndex, err := binLogger.Backup(&buf)
+	if err != nil {
+		t.Fatalf("failed to back up bin logger: %v", err)
+	}
+	if finalIndex != 4 {
+		t.Fatalf("unexpected backup final index: got %d, want 4", finalIndex)
+	}
+
+	if err := binLogger.Close(); err != nil {
+		t.Fatalf("failed to close bin logger: %v", err)
+	}
+
+	restoreWal, err := os.MkdirTemp("", "wal-restore")
+	if err != nil {
+		t.Fatalf("failed to create restore wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(restoreWal); err != nil {
+			t.Fatalf("failed to remove restore wal dir: %v", err)
+		}
+	}()
+	if err := os.RemoveAll(restoreWal); err != nil {
+		t.Fatalf("failed to clear restore wal dir: %v", err)
+	}
+
+	restoreSnap, err := os.MkdirTemp("", "snap-restore")
+	if err != nil {
+		t.Fatalf("failed to create restore snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(restoreSnap); err != nil {
+			t.Fatalf("failed to remove restore snap dir: %v", err)
+		}
+	}()
+	if err := os.RemoveAll(restoreSnap); err != nil {
+		t.Fatalf("failed to clear restore snap dir: %v", err)
+	}
+
+	restored := gobinlogger.NewBinLogger(restoreWal, restoreSnap, 0)
+	if err := restored.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("failed to restore bin logger: %v", err)
+	}
+
+	if err := restored.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start restored bin logger: %v", err)
+	}
+
+	prevIndex, currIndex, entries, release, err := restored.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot on restored bin logger: %v", err)
+	}
+	if prevIndex != 0 || currIndex != 4 {
+		t.Fatalf("unexpected restored snapshot indices: got (%d, %d), want (0, 4)", prevIndex, currIndex)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("unexpected number of restored entries: got %d, want 4", len(entries))
+	}
+	want := []string{"first entry", "second entry", "third entry", "fourth entry"}
+	for i, ent := range entries {
+		if string(ent.Data) != want[i] {
+			t.Fatalf("unexpected restored entry %d: got %q, want %q", i, ent.Data, want[i])
+		}
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+}
+
+func TestBinLoggerRestoreRejectsCorruptedFrame(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := binLogger.Backup(&buf); err != nil {
+		t.Fatalf("failed to back up bin logger: %v", err)
+	}
+	if err := binLogger.Close(); err != nil {
+		t.Fatalf("failed to close bin logger: %v", err)
+	}
+
+	corrupted := buf.Bytes()
+	// Flip a byte inside the first WAL entry frame's payload: 4 (magic) +
+	// 4 (format version) + 8 (empty snapshot metadata frame: length+crc,
+	// no snapshot was ever taken) + 8 (entry count) lands right on the
+	// first entry frame's length+crc header, so +8 more lands in its data.
+	payloadOffset := 4 + 4 + 8 + 8 + 8
+	corrupted[payloadOffset] ^= 0xff
+
+	restoreWal, err := os.MkdirTemp("", "wal-restore")
+	if err != nil {
+		t.Fatalf("failed to create restore wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(restoreWal); err != nil {
+			t.Fatalf("failed to remove restore wal dir: %v", err)
+		}
+	}()
+	if err := os.RemoveAll(restoreWal); err != nil {
+		t.Fatalf("failed to clear restore wal dir: %v", err)
+	}
+
+	restoreSnap, err := os.MkdirTemp("", "snap-restore")
+	if err != nil {
+		t.Fatalf("failed to create restore snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(restoreSnap); err != nil {
+			t.Fatalf("failed to remove restore snap dir: %v", err)
+		}
+	}()
+	if err := os.RemoveAll(restoreSnap); err != nil {
+		t.Fatalf("failed to clear restore snap dir: %v", err)
+	}
+
+	restored := gobinlogger.NewBinLogger(restoreWal, restoreSnap, 0)
+	if err := restored.Restore(bytes.NewReader(corrupted)); err == nil {
+		t.Fatalf("expected restore to reject a corrupted backup stream, got nil error")
+	}
+}
@@ -0,0 +1,228 @@
+package gobinlogger_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	gobinlogger "github.com/harikube/go-binlogger/pkg/binlogger"
+)
+
+// xorEncrypter is a minimal Encrypter stand-in for tests: it XORs the
+// plaintext against its key byte so RotateKey/decrypt coverage does not
+// depend on a real AEAD implementation.
+type xorEncrypter struct {
+	keyID string
+	key   byte
+}
+
+func (e xorEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	out := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		out[i] = b ^ e.key
+	}
+	return out, nil
+}
+
+func (e xorEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.Encrypt(ciphertext)
+}
+
+func (e xorEncrypter) KeyID() string {
+	return e.keyID
+}
+
+func TestBinLoggerRotateKeyRoundTrip(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	keyA := xorEncrypter{keyID: "key-a", key: 0xaa}
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithEncrypter(keyA))
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry")}); err != nil {
+		t.Fatalf("failed to log data under key-a: %v", err)
+	}
+
+	// Snapshot while key-a is still current, confirming the entry it
+	// encrypted decrypts correctly.
+	_, _, entries, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot under key-a: %v", err)
+	}
+	if len(entries) != 1 || string(entries[0].Data) != "first entry" {
+		t.Fatalf("unexpected entries under key-a: %+v", entries)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+
+	keyB := xorEncrypter{keyID: "key-b", key: 0x55}
+	if err := binLogger.RotateKey(keyB); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("second entry")}); err != nil {
+		t.Fatalf("failed to log data under key-b: %v", err)
+	}
+
+	// Snapshot again now that key-b is current, confirming that new entries
+	// encrypt/decrypt under the rotated key. RotateKey's own snapshot above
+	// found nothing past index 1, and this one starts after index 1, so
+	// neither reads the entry written under key-a.
+	_, _, entries, release, err = binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot under key-b: %v", err)
+	}
+	if len(entries) != 1 || string(entries[0].Data) != "second entry" {
+		t.Fatalf("unexpected entries under key-b: %+v", entries)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+}
+
+// TestBinLoggerRotateKeyConcurrentWithLog runs RotateKey and Log from
+// separate goroutines so `go test -race` catches any unsynchronized access
+// to encrypter/decrypters; Log itself takes no lock by design, so this only
+// passes if RotateKey's mutation and Log/CreateSnapshot's reads go through
+// a shared guard.
+func TestBinLoggerRotateKeyConcurrentWithLog(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	keyA := xorEncrypter{keyID: "key-a", key: 0xaa}
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithEncrypter(keyA))
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if err := binLogger.Log([][]byte{[]byte(fmt.Sprintf("entry-%d", i))}); err != nil {
+				t.Errorf("failed to log data concurrently with rotation: %v", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			key := xorEncrypter{keyID: fmt.Sprintf("key-%d", i), key: byte(i)}
+			if err := binLogger.RotateKey(key); err != nil {
+				t.Errorf("failed to rotate key concurrently with logging: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
+// TestBinLoggerEncrypterIntroducedOnExistingPlaintextWAL confirms that
+// enabling an Encrypter after entries were already logged in plaintext
+// (the realistic upgrade path) does not break reads of those legacy
+// entries: they pass through undecrypted instead of hard-failing on
+// unmarshalEnvelope.
+func TestBinLoggerEncrypterIntroducedOnExistingPlaintextWAL(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+	if err := binLogger.Log([][]byte{[]byte("legacy plaintext entry")}); err != nil {
+		t.Fatalf("failed to log plaintext data: %v", err)
+	}
+	if err := binLogger.Close(); err != nil {
+		t.Fatalf("failed to close bin logger: %v", err)
+	}
+
+	key := xorEncrypter{keyID: "key-a", key: 0xaa}
+	reopened := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithEncrypter(key))
+	if err := reopened.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger with encryption enabled: %v", err)
+	}
+
+	if err := reopened.Log([][]byte{[]byte("new encrypted entry")}); err != nil {
+		t.Fatalf("failed to log encrypted data: %v", err)
+	}
+
+	_, _, entries, release, err := reopened.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot spanning legacy plaintext and encrypted entries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of entries: got %d, want 2", len(entries))
+	}
+	if string(entries[0].Data) != "legacy plaintext entry" {
+		t.Fatalf("unexpected legacy entry data: got %q, want %q", entries[0].Data, "legacy plaintext entry")
+	}
+	if string(entries[1].Data) != "new encrypted entry" {
+		t.Fatalf("unexpected new entry data: got %q, want %q", entries[1].Data, "new encrypted entry")
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"os"
-	"strconv"
-	"strings"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,29 +17,46 @@ import (
 	"go.uber.org/zap"
 )
 
-var (
-	ErrIndexOverflow = fmt.Errorf("BinLogger index overflow")
-
-	raftpbHardState = raftpb.HardState{}
-)
+var ErrIndexOverflow = fmt.Errorf("BinLogger index overflow")
 
 type BinLogger struct {
-	walDir        string
-	snapDir       string
-	index         atomic.Uint64
-	lastSnapIndex atomic.Uint64
-	logMutex      sync.Mutex
-	snapshotter   *snap.Snapshotter
-	syncInterval  time.Duration
-	storage       storage.Storage
+	walDir          string
+	snapDir         string
+	index           atomic.Uint64
+	lastSnapIndex   atomic.Uint64
+	logMutex        sync.Mutex
+	snapshotter     *snap.Snapshotter
+	syncInterval    time.Duration
+	storage         storage.Storage
+	maxSnapshots    int
+	sidecarSuffixes []string
+	cryptoMu        sync.RWMutex
+	encrypter       Encrypter
+	decrypters      map[string]Encrypter
+	readersDir      string
+	readersMu       sync.Mutex
+	readers         map[string]*Reader
+	notifyMu        sync.Mutex
+	notifyCh        chan struct{}
+	stateProvider   StateProvider
+	stateConsumer   StateConsumer
 }
 
-func NewBinLogger(walDir, snapDir string, syncInterval time.Duration) *BinLogger {
-	return &BinLogger{
+func NewBinLogger(walDir, snapDir string, syncInterval time.Duration, opts ...Option) *BinLogger {
+	bl := &BinLogger{
 		walDir:       walDir,
 		snapDir:      snapDir,
 		syncInterval: syncInterval,
+		maxSnapshots: defaultMaxSnapshots,
+		readersDir:   filepath.Join(snapDir, "readers"),
+		notifyCh:     make(chan struct{}),
 	}
+
+	for _, opt := range opts {
+		opt(bl)
+	}
+
+	return bl
 }
 
 func (bl *BinLogger) Start(ctx context.Context, wg ...*sync.WaitGroup) error {
@@ -50,33 +65,36 @@ func (bl *BinLogger) Start(ctx context.Context, wg ...*sync.WaitGroup) error {
 	var w *wal.WAL
 	var err error
 	if wal.Exist(bl.walDir) {
-		snaps, err := os.ReadDir(bl.snapDir)
+		walSnaps, err := wal.ValidSnapshotEntries(zap.NewNop(), bl.walDir)
 		if err != nil {
-			return fmt.Errorf("failed to read snapshots dir at %s: %v", bl.snapDir, err)
+			return fmt.Errorf("failed to read WAL snapshot records at %s: %v", bl.walDir, err)
 		}
 
-		index := uint64(0)
-		if len(snaps) > 0 {
-			if !strings.HasSuffix(snaps[len(snaps)-1].Name(), ".snap") {
-				return fmt.Errorf("invalid latest snapshot file found at %s: %s", bl.snapDir, snaps[len(snaps)-1].Name())
-			}
-
-			parts := strings.Split(strings.TrimSuffix(snaps[len(snaps)-1].Name(), ".snap"), "-")
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid latest snapshot file name found at %s: %s", bl.snapDir, snaps[len(snaps)-1].Name())
-			}
+		snapshot, err := bl.snapshotter.LoadNewestAvailable(walSnaps)
+		if err != nil && err != snap.ErrNoSnapshot {
+			return fmt.Errorf("failed to load newest available snapshot at %s: %v", bl.snapDir, err)
+		}
 
-			if index, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
-				return fmt.Errorf("failed to parse snapshot file name %s: %v", snaps[len(snaps)-1].Name(), err)
+		var walSnap walpb.Snapshot
+		if snapshot != nil {
+			walSnap = walpb.Snapshot{
+				Index: snapshot.Metadata.Index,
+				Term:  snapshot.Metadata.Term,
 			}
 		}
 
-		walSnap := walpb.Snapshot{
-			Index: index,
-		}
+		bl.index.Store(walSnap.Index)
+		bl.lastSnapIndex.Store(walSnap.Index)
 
-		bl.index.Store(index)
-		bl.lastSnapIndex.Store(index)
+		if snapshot != nil && bl.stateConsumer != nil {
+			data, err := bl.decryptData(snapshot.Data)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt snapshot state at %s: %v", bl.snapDir, err)
+			}
+			if err := bl.stateConsumer(walSnap.Index, data); err != nil {
+				return fmt.Errorf("failed to consume snapshot state at %s: %v", bl.snapDir, err)
+			}
+		}
 
 		w, err = wal.Open(zap.NewNop(), bl.walDir, walSnap)
 		if err != nil {
@@ -158,17 +176,28 @@ func (bl *BinLogger) Log(data [][]byte) error {
 	entries := make([]raftpb.Entry, len(data))
 	for i := range data {
 		currentIndex++
+
+		entryData, err := bl.encryptData(data[i])
+		if err != nil {
+			return fmt.Errorf("failed to encrypt entry %d: %v", currentIndex, err)
+		}
+
 		entries[i] = raftpb.Entry{
 			Index: currentIndex,
 			Type:  raftpb.EntryNormal,
-			Data:  data[i],
+			Data:  entryData,
 		}
 	}
 
-	if err := bl.storage.Save(raftpbHardState, entries); err != nil {
+	if err := bl.storage.Save(raftpb.HardState{Commit: currentIndex}, entries); err != nil {
 		return fmt.Errorf("failed to log entries to %s: %v", bl.walDir, err)
 	}
 
+	bl.notifyMu.Lock()
+	close(bl.notifyCh)
+	bl.notifyCh = make(chan struct{})
+	bl.notifyMu.Unlock()
+
 	if bl.syncInterval == 0 {
 		if err := bl.storage.Sync(); err != nil {
 			return fmt.Errorf("failed to sync WAL after snapshot: %v", err)
@@ -202,6 +231,10 @@ func (bl *BinLogger) CreateSnapshot() (uint64, uint64, []raftpb.Entry, func(bool
 	prevSnapIndex := bl.lastSnapIndex.Load()
 	currentIndex := bl.index.Load()
 
+	if minAck, ok := bl.minReaderAck(); ok && minAck < currentIndex {
+		currentIndex = minAck
+	}
+
 	if prevSnapIndex >= currentIndex {
 		bl.logMutex.Unlock()
 		return 0, 0, nil, nil, nil
@@ -231,8 +264,31 @@ func (bl *BinLogger) CreateSnapshot() (uint64, uint64, []raftpb.Entry, func(bool
 
 	var filtered []raftpb.Entry
 	for _, ent := range ents {
-		if ent.Index <= walSnapshot.Index {
-			filtered = append(filtered, ent)
+		if ent.Index > walSnapshot.Index {
+			continue
+		}
+
+		decData, err := bl.decryptData(ent.Data)
+		if err != nil {
+			bl.logMutex.Unlock()
+			return 0, 0, nil, nil, fmt.Errorf("failed to decrypt entry %d: %v", ent.Index, err)
+		}
+		ent.Data = decData
+
+		filtered = append(filtered, ent)
+	}
+
+	var snapData []byte
+	if bl.stateProvider != nil {
+		state, err := bl.stateProvider(prevSnapIndex, walSnapshot.Index, filtered)
+		if err != nil {
+			bl.logMutex.Unlock()
+			return 0, 0, nil, nil, fmt.Errorf("failed to build snapshot state for %s (%d - %d): %v", bl.snapDir, prevSnapIndex, walSnapshot.Index, err)
+		}
+
+		if snapData, err = bl.encryptData(state); err != nil {
+			bl.logMutex.Unlock()
+			return 0, 0, nil, nil, fmt.Errorf("failed to encrypt snapshot state for %s: %v", bl.snapDir, err)
 		}
 	}
 
@@ -240,7 +296,7 @@ func (bl *BinLogger) CreateSnapshot() (uint64, uint64, []raftpb.Entry, func(bool
 		Metadata: raftpb.SnapshotMetadata{
 			Index: walSnapshot.Index,
 		},
-		Data: nil,
+		Data: snapData,
 	}
 
 	if err := bl.storage.SaveSnap(snashot); err != nil {
@@ -259,11 +315,15 @@ func (bl *BinLogger) CreateSnapshot() (uint64, uint64, []raftpb.Entry, func(bool
 	releaseFn := func(ok bool) error {
 		defer bl.logMutex.Unlock()
 
-		if ok {
-			return bl.storage.Release(snashot)
+		if !ok {
+			return nil
 		}
 
-		return nil
+		if err := bl.storage.Release(snashot); err != nil {
+			return err
+		}
+
+		return bl.pruneOrphanSnapshots()
 	}
 
 	return prevSnapIndex, walSnapshot.Index, filtered, releaseFn, nil
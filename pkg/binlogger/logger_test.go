@@ -3,6 +3,7 @@ package gobinlogger_test
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -142,3 +143,95 @@ func TestBinLogger(t *testing.T) {
 		t.Fatalf("failed to release snapshot lock: %v", err)
 	}
 }
+
+func TestBinLoggerFallsBackToOlderSnapshotWhenNewestIsCorrupt(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry"), []byte("third entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	_, _, _, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("fourth entry"), []byte("fifth entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	_, _, _, release, err = binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+
+	if err := binLogger.Close(); err != nil {
+		t.Fatalf("failed to close bin logger: %v", err)
+	}
+
+	snaps, err := os.ReadDir(tmpSnap)
+	if err != nil {
+		t.Fatalf("failed to read snap dir: %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("unexpected number of snapshot files: got %d, want 2", len(snaps))
+	}
+
+	newest := snaps[len(snaps)-1].Name()
+	if err := os.WriteFile(filepath.Join(tmpSnap, newest), []byte("not a valid snapshot"), 0o640); err != nil {
+		t.Fatalf("failed to corrupt newest snapshot: %v", err)
+	}
+
+	binLogger = gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger after corrupting newest snapshot: %v", err)
+	}
+
+	prevIndex, currIndex, entries, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot after fallback: %v", err)
+	}
+
+	if prevIndex != 3 || currIndex != 5 {
+		t.Fatalf("unexpected snapshot indices after fallback: got (%d, %d), want (3, 5)", prevIndex, currIndex)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of entries after fallback: got %d, want 2", len(entries))
+	} else if string(entries[0].Data) != "fourth entry" || string(entries[1].Data) != "fifth entry" {
+		t.Fatalf("unexpected entries data after fallback")
+	}
+
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+}
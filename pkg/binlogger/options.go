@@ -0,0 +1,27 @@
+package gobinlogger
+
+// defaultMaxSnapshots is the number of most recent .snap files kept in
+// snapDir once orphan cleanup runs, when WithMaxSnapshots is not used.
+const defaultMaxSnapshots = 5
+
+// Option configures a BinLogger at construction time.
+type Option func(*BinLogger)
+
+// WithMaxSnapshots sets how many of the most recent snapshots are retained
+// in snapDir when orphan snapshots are pruned after a successful
+// CreateSnapshot release. Pruning is by count only: snapshots still
+// referenced by the WAL's snapshot records are not exempt from this limit.
+func WithMaxSnapshots(n int) Option {
+	return func(bl *BinLogger) {
+		bl.maxSnapshots = n
+	}
+}
+
+// WithSnapshotSidecarSuffix registers a filename suffix for application-side
+// files written next to a snapshot (e.g. ".snap.db"). Orphan cleanup removes
+// the sidecar alongside its snapshot whenever the snapshot itself is pruned.
+func WithSnapshotSidecarSuffix(suffix string) Option {
+	return func(bl *BinLogger) {
+		bl.sidecarSuffixes = append(bl.sidecarSuffixes, suffix)
+	}
+}
@@ -0,0 +1,241 @@
+package gobinlogger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.uber.org/zap"
+)
+
+// Reader tails a BinLogger's WAL for a single named consumer (e.g. a
+// metrics exporter, a replicator, an audit sink), without holding the log
+// mutex or blocking writers. Several Readers can follow the same BinLogger
+// concurrently, each with its own cursor.
+type Reader struct {
+	bl         *BinLogger
+	name       string
+	nextIndex  uint64
+	ackedIndex uint64
+	buffered   []raftpb.Entry
+}
+
+// NewReader opens a Reader named name that starts tailing the WAL at
+// startIndex. name must be unique among the BinLogger's live readers; it is
+// also used as the filename for the reader's Ack checkpoint under
+// readersDir. If a checkpoint for name already exists (i.e. name is reused
+// after a crash or restart), the reader resumes at the entry after the
+// acked index instead of startIndex.
+func (bl *BinLogger) NewReader(name string, startIndex uint64) (*Reader, error) {
+	if name == "" {
+		return nil, fmt.Errorf("reader name must not be empty")
+	}
+
+	if err := os.MkdirAll(bl.readersDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create readers dir at %s: %v", bl.readersDir, err)
+	}
+
+	acked, resumed, err := readAckFile(filepath.Join(bl.readersDir, name+".ack"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ack checkpoint for reader %s: %v", name, err)
+	}
+
+	next := startIndex
+	if resumed {
+		next = acked + 1
+	} else if startIndex > 0 {
+		acked = startIndex - 1
+	}
+
+	r := &Reader{
+		bl:         bl,
+		name:       name,
+		nextIndex:  next,
+		ackedIndex: acked,
+	}
+
+	bl.registerReader(r)
+
+	return r, nil
+}
+
+func readAckFile(path string) (index uint64, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	index, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid ack checkpoint contents: %v", err)
+	}
+
+	return index, true, nil
+}
+
+// Next returns the next WAL entry at or after the reader's cursor, blocking
+// until it is appended or ctx is done. Entries are decrypted the same way
+// CreateSnapshot decrypts them.
+func (r *Reader) Next(ctx context.Context) (raftpb.Entry, error) {
+	for {
+		if len(r.buffered) > 0 {
+			ent := r.buffered[0]
+			r.buffered = r.buffered[1:]
+			r.nextIndex = ent.Index + 1
+			return ent, nil
+		}
+
+		want := r.nextIndex
+		if want == 0 {
+			want = 1
+		}
+		if r.bl.index.Load() < want {
+			if err := r.bl.waitForIndex(ctx, want); err != nil {
+				return raftpb.Entry{}, err
+			}
+			continue
+		}
+
+		ents, err := r.readFrom(r.nextIndex)
+		if err != nil {
+			return raftpb.Entry{}, err
+		}
+		if len(ents) == 0 {
+			continue
+		}
+
+		r.buffered = ents
+	}
+}
+
+func (r *Reader) readFrom(index uint64) ([]raftpb.Entry, error) {
+	walSnap := walpb.Snapshot{}
+	if index > 0 {
+		walSnap.Index = index - 1
+	}
+
+	w, err := wal.OpenForRead(zap.NewNop(), r.bl.walDir, walSnap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wal for reader %s: %v", r.name, err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			fmt.Printf("failed to close wal reader for %s: %v", r.name, err)
+		}
+	}()
+
+	_, _, ents, err := w.ReadAll()
+	if err != nil && err != wal.ErrSnapshotNotFound {
+		return nil, fmt.Errorf("failed to read wal for reader %s: %v", r.name, err)
+	}
+
+	var out []raftpb.Entry
+	for _, ent := range ents {
+		if ent.Index < index {
+			continue
+		}
+
+		decData, err := r.bl.decryptData(ent.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt entry %d for reader %s: %v", ent.Index, r.name, err)
+		}
+		ent.Data = decData
+
+		out = append(out, ent)
+	}
+
+	return out, nil
+}
+
+// Ack records index as the highest entry this reader has fully processed.
+// CreateSnapshot caps its snapshot index, and so the WAL compaction done on
+// its release, at the lowest Ack among all of a BinLogger's live readers.
+func (r *Reader) Ack(index uint64) error {
+	path := filepath.Join(r.bl.readersDir, r.name+".ack")
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(index, 10)), 0o640); err != nil {
+		return fmt.Errorf("failed to write ack checkpoint for reader %s: %v", r.name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to install ack checkpoint for reader %s: %v", r.name, err)
+	}
+
+	r.bl.readersMu.Lock()
+	r.ackedIndex = index
+	r.bl.readersMu.Unlock()
+
+	return nil
+}
+
+// Close stops tailing and releases the reader's hold on WAL compaction. It
+// does not remove the reader's on-disk Ack checkpoint.
+func (r *Reader) Close() error {
+	r.bl.unregisterReader(r.name)
+	return nil
+}
+
+func (bl *BinLogger) registerReader(r *Reader) {
+	bl.readersMu.Lock()
+	defer bl.readersMu.Unlock()
+
+	if bl.readers == nil {
+		bl.readers = make(map[string]*Reader)
+	}
+	bl.readers[r.name] = r
+}
+
+func (bl *BinLogger) unregisterReader(name string) {
+	bl.readersMu.Lock()
+	defer bl.readersMu.Unlock()
+
+	delete(bl.readers, name)
+}
+
+// minReaderAck returns the lowest Ack index among the BinLogger's live
+// readers, and whether any reader is currently registered.
+func (bl *BinLogger) minReaderAck() (uint64, bool) {
+	bl.readersMu.Lock()
+	defer bl.readersMu.Unlock()
+
+	if len(bl.readers) == 0 {
+		return 0, false
+	}
+
+	min := uint64(0)
+	first := true
+	for _, r := range bl.readers {
+		if first || r.ackedIndex < min {
+			min = r.ackedIndex
+			first = false
+		}
+	}
+
+	return min, true
+}
+
+// waitForIndex blocks until bl.index reaches at least index or ctx is done.
+func (bl *BinLogger) waitForIndex(ctx context.Context, index uint64) error {
+	for bl.index.Load() < index {
+		bl.notifyMu.Lock()
+		ch := bl.notifyCh
+		bl.notifyMu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
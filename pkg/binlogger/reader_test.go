@@ -0,0 +1,120 @@
+package gobinlogger_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	gobinlogger "github.com/harikube/go-binlogger/pkg/binlogger"
+)
+
+func TestBinLoggerReaderMultiReaderNextAck(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	fast, err := binLogger.NewReader("fast", 0)
+	if err != nil {
+		t.Fatalf("failed to create fast reader: %v", err)
+	}
+	slow, err := binLogger.NewReader("slow", 0)
+	if err != nil {
+		t.Fatalf("failed to create slow reader: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, r := range []*gobinlogger.Reader{fast, slow} {
+		ent, err := r.Next(ctx)
+		if err != nil {
+			t.Fatalf("failed to read first entry for %p: %v", r, err)
+		}
+		if string(ent.Data) != "first entry" {
+			t.Fatalf("unexpected first entry data: got %q, want %q", ent.Data, "first entry")
+		}
+	}
+
+	// Only fast acks the first entry and reads ahead to the second; slow
+	// stays behind, so WAL compaction (via minReaderAck) must still be
+	// capped at slow's unacked cursor.
+	if err := fast.Ack(1); err != nil {
+		t.Fatalf("failed to ack fast reader: %v", err)
+	}
+
+	ent, err := fast.Next(ctx)
+	if err != nil {
+		t.Fatalf("failed to read second entry for fast reader: %v", err)
+	}
+	if string(ent.Data) != "second entry" {
+		t.Fatalf("unexpected second entry data: got %q, want %q", ent.Data, "second entry")
+	}
+	if err := fast.Ack(2); err != nil {
+		t.Fatalf("failed to ack fast reader: %v", err)
+	}
+
+	_, snapIndex, _, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	// minReaderAck caps currentIndex at slow's unacked index (0), which
+	// equals prevSnapIndex, so CreateSnapshot declines to snapshot at all
+	// until slow acks forward.
+	if snapIndex != 0 || release != nil {
+		t.Fatalf("expected snapshot to be withheld while slow reader is unacked, got index %d, release != nil: %v", snapIndex, release != nil)
+	}
+
+	if err := slow.Ack(1); err != nil {
+		t.Fatalf("failed to ack slow reader: %v", err)
+	}
+
+	// Log one more entry and confirm a reader opened under the same name
+	// after a simulated crash resumes from its persisted Ack rather than
+	// re-reading from scratch.
+	if err := binLogger.Log([][]byte{[]byte("third entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	if err := slow.Close(); err != nil {
+		t.Fatalf("failed to close slow reader: %v", err)
+	}
+
+	resumed, err := binLogger.NewReader("slow", 0)
+	if err != nil {
+		t.Fatalf("failed to reopen slow reader: %v", err)
+	}
+
+	ent, err = resumed.Next(ctx)
+	if err != nil {
+		t.Fatalf("failed to read resumed entry: %v", err)
+	}
+	if string(ent.Data) != "second entry" {
+		t.Fatalf("resumed reader did not continue from its Ack: got %q, want %q", ent.Data, "second entry")
+	}
+}
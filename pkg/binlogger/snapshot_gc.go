@@ -0,0 +1,63 @@
+package gobinlogger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pruneOrphanSnapshots removes .snap files (and any registered sidecar
+// files) beyond the maxSnapshots most recent snapshots. It is invoked from
+// releaseFn(true) after a successful CreateSnapshot release, mirroring the
+// orphan cleanup etcd added for issue #10219.
+//
+// Snapshot files are evicted strictly by count, regardless of whether the
+// WAL still carries a SnapshotType record for them: the WAL in this package
+// is never rotated or compacted, so every snapshot this BinLogger has ever
+// taken stays in wal.ValidSnapshotEntries' output forever, and treating that
+// as a retention signal would make maxSnapshots a no-op for real snapshots.
+// It is safe to drop the file anyway — snap.Snapshotter.LoadNewestAvailable
+// walks the snapshot files that are actually still on disk and matches each
+// against the WAL's (still complete) record list, so it simply falls back
+// further whenever a newer snapshot's file is missing.
+func (bl *BinLogger) pruneOrphanSnapshots() error {
+	files, err := os.ReadDir(bl.snapDir)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots dir at %s: %v", bl.snapDir, err)
+	}
+
+	var snapFiles []string
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".snap") {
+			snapFiles = append(snapFiles, f.Name())
+		}
+	}
+	sort.Strings(snapFiles)
+
+	keep := bl.maxSnapshots
+	if keep < 0 {
+		keep = 0
+	}
+
+	for i, name := range snapFiles {
+		if len(snapFiles)-i <= keep {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(bl.snapDir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove orphaned snapshot %s: %v", name, err)
+		}
+
+		base := strings.TrimSuffix(name, ".snap")
+		for _, suffix := range bl.sidecarSuffixes {
+			sidecar := filepath.Join(bl.snapDir, base+suffix)
+			if err := os.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove orphaned snapshot sidecar %s: %v", sidecar, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package gobinlogger_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gobinlogger "github.com/harikube/go-binlogger/pkg/binlogger"
+)
+
+// TestBinLoggerPrunesOrphanSnapshotsAndSidecars seeds the snap dir with a
+// fabricated orphan .snap file (and matching sidecar) that was never passed
+// through SaveSnap, and checks that a release(true) pass removes it along
+// with the older of two real snapshots once maxSnapshots is exceeded: only
+// the single newest snapshot file survives.
+func TestBinLoggerPrunesOrphanSnapshotsAndSidecars(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0,
+		gobinlogger.WithMaxSnapshots(1),
+		gobinlogger.WithSnapshotSidecarSuffix(".snap.db"),
+	)
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry"), []byte("third entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	_, firstSnapIndex, _, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create first snapshot: %v", err)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release first snapshot lock: %v", err)
+	}
+
+	// index 1 sits strictly between the implicit index-0 snapshot record the
+	// WAL always carries and firstSnapIndex, so it is neither the initial
+	// record nor a real one this BinLogger ever saved.
+	orphanNames := []string{
+		fmt.Sprintf("0000000000000000-%016x.snap", uint64(1)),
+		fmt.Sprintf("0000000000000000-%016x.snap.db", uint64(1)),
+	}
+	for _, name := range orphanNames {
+		if err := os.WriteFile(filepath.Join(tmpSnap, name), []byte("orphan"), 0o640); err != nil {
+			t.Fatalf("failed to write orphan file %s: %v", name, err)
+		}
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("fourth entry"), []byte("fifth entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	_, secondSnapIndex, _, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create second snapshot: %v", err)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release second snapshot lock: %v", err)
+	}
+
+	for _, name := range orphanNames {
+		if _, err := os.Stat(filepath.Join(tmpSnap, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected orphan file %s to be pruned, stat err: %v", name, err)
+		}
+	}
+
+	// maxSnapshots is 1, so the older real snapshot must be evicted too, not
+	// just the orphan: WAL snapshot records are never purged in this
+	// package, and retention must not be a no-op just because a real
+	// snapshot is still "referenced" by one of those records.
+	firstSnap := fmt.Sprintf("0000000000000000-%016x.snap", firstSnapIndex)
+	if _, err := os.Stat(filepath.Join(tmpSnap, firstSnap)); !os.IsNotExist(err) {
+		t.Fatalf("expected older real snapshot to be evicted past maxSnapshots, stat err: %v", err)
+	}
+
+	secondSnap := fmt.Sprintf("0000000000000000-%016x.snap", secondSnapIndex)
+	if _, err := os.Stat(filepath.Join(tmpSnap, secondSnap)); err != nil {
+		t.Fatalf("expected newest snapshot to survive pruning: %v", err)
+	}
+}
+
+// TestBinLoggerMaxSnapshotsEvictsRealSnapshots runs many successive
+// CreateSnapshot/release(true) cycles and checks that only the
+// maxSnapshots most recent real .snap files remain, confirming the option
+// actually bounds disk usage rather than being defeated by every snapshot
+// staying "referenced" in the WAL's never-purged snapshot records.
+func TestBinLoggerMaxSnapshotsEvictsRealSnapshots(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	const maxSnapshots = 2
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithMaxSnapshots(maxSnapshots))
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := binLogger.Log([][]byte{[]byte("entry")}); err != nil {
+			t.Fatalf("failed to log data on iteration %d: %v", i, err)
+		}
+		_, _, _, release, err := binLogger.CreateSnapshot()
+		if err != nil {
+			t.Fatalf("failed to create snapshot on iteration %d: %v", i, err)
+		}
+		if err := release(true); err != nil {
+			t.Fatalf("failed to release snapshot lock on iteration %d: %v", i, err)
+		}
+	}
+
+	files, err := os.ReadDir(tmpSnap)
+	if err != nil {
+		t.Fatalf("failed to read snap dir: %v", err)
+	}
+
+	var snapCount int
+	for _, f := range files {
+		if filepath.Ext(f.Name()) == ".snap" {
+			snapCount++
+		}
+	}
+	if snapCount != maxSnapshots {
+		t.Fatalf("unexpected snap file count after 10 snapshot cycles: got %d, want %d", snapCount, maxSnapshots)
+	}
+}
@@ -0,0 +1,29 @@
+package gobinlogger
+
+import "go.etcd.io/raft/v3/raftpb"
+
+// StateProvider builds the application state to embed in a snapshot, given
+// the range of entries (prevIndex, currIndex] that snapshot compacts.
+type StateProvider func(prevIndex, currIndex uint64, entries []raftpb.Entry) ([]byte, error)
+
+// StateConsumer rehydrates application state from a snapshot loaded at
+// Start, so the application can skip re-applying WAL entries at or below
+// index.
+type StateConsumer func(index uint64, data []byte) error
+
+// WithStateProvider registers fn to produce the snapshot.Data for each
+// CreateSnapshot call, turning BinLogger from a raw append-only log into a
+// snapshot+log store usable to bootstrap a replica.
+func WithStateProvider(fn StateProvider) Option {
+	return func(bl *BinLogger) {
+		bl.stateProvider = fn
+	}
+}
+
+// WithStateConsumer registers fn to be called once, during Start, with the
+// data from the newest available snapshot.
+func WithStateConsumer(fn StateConsumer) Option {
+	return func(bl *BinLogger) {
+		bl.stateConsumer = fn
+	}
+}
@@ -0,0 +1,78 @@
+package gobinlogger_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	gobinlogger "github.com/harikube/go-binlogger/pkg/binlogger"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestBinLoggerStateProviderConsumerRoundTrip(t *testing.T) {
+	tmpWal, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatalf("failed to create temp wal dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWal); err != nil {
+			t.Fatalf("failed to remove temp wal dir: %v", err)
+		}
+	}()
+
+	tmpSnap, err := os.MkdirTemp("", "snap")
+	if err != nil {
+		t.Fatalf("failed to create temp snap dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpSnap); err != nil {
+			t.Fatalf("failed to remove temp snap dir: %v", err)
+		}
+	}()
+
+	provider := func(prevIndex, currIndex uint64, entries []raftpb.Entry) ([]byte, error) {
+		return []byte(fmt.Sprintf("count=%d", len(entries))), nil
+	}
+
+	binLogger := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithStateProvider(provider))
+	if err := binLogger.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start bin logger: %v", err)
+	}
+
+	if err := binLogger.Log([][]byte{[]byte("first entry"), []byte("second entry"), []byte("third entry")}); err != nil {
+		t.Fatalf("failed to log data: %v", err)
+	}
+
+	_, currIndex, _, release, err := binLogger.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if err := release(true); err != nil {
+		t.Fatalf("failed to release snapshot lock: %v", err)
+	}
+
+	if err := binLogger.Close(); err != nil {
+		t.Fatalf("failed to close bin logger: %v", err)
+	}
+
+	var consumedIndex uint64
+	var consumedData []byte
+	consumer := func(index uint64, data []byte) error {
+		consumedIndex = index
+		consumedData = data
+		return nil
+	}
+
+	reopened := gobinlogger.NewBinLogger(tmpWal, tmpSnap, 0, gobinlogger.WithStateConsumer(consumer))
+	if err := reopened.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start reopened bin logger: %v", err)
+	}
+
+	if consumedIndex != currIndex {
+		t.Fatalf("unexpected index passed to state consumer: got %d, want %d", consumedIndex, currIndex)
+	}
+	if string(consumedData) != "count=3" {
+		t.Fatalf("unexpected data passed to state consumer: got %q, want %q", consumedData, "count=3")
+	}
+}